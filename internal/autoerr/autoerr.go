@@ -0,0 +1,37 @@
+// Package autoerr defines the typed errors returned by the automation
+// service, each carrying a stable Code and an HTTP status so the handler
+// layer (via ErrorMapper) never has to guess at a status code or let a
+// frontend parse English error strings.
+package autoerr
+
+import "net/http"
+
+// CodedError is a typed error that knows which HTTP status and stable code
+// it maps to. Use errors.As against this interface to recover it from a
+// wrapped error chain.
+type CodedError interface {
+	error
+	Code() string
+	HTTPStatus() int
+}
+
+type codedError struct {
+	code    string
+	message string
+	status  int
+}
+
+func (e *codedError) Error() string   { return e.message }
+func (e *codedError) Code() string    { return e.code }
+func (e *codedError) HTTPStatus() int { return e.status }
+
+var (
+	ErrImageTooLarge     CodedError = &codedError{"IMAGE_TOO_LARGE", "image is too large", http.StatusRequestEntityTooLarge}
+	ErrInvalidExtension  CodedError = &codedError{"INVALID_EXTENSION", "invalid image extension", http.StatusUnsupportedMediaType}
+	ErrUnsupportedFormat CodedError = &codedError{"UNSUPPORTED_FORMAT", "image format is recognized but not supported", http.StatusUnsupportedMediaType}
+	ErrMIMEMismatch      CodedError = &codedError{"MIME_MISMATCH", "file content does not match an image type", http.StatusUnsupportedMediaType}
+	ErrCorruptImage      CodedError = &codedError{"CORRUPT_IMAGE", "image file is corrupt", http.StatusUnprocessableEntity}
+	ErrNotFound          CodedError = &codedError{"NOT_FOUND", "resource not found", http.StatusNotFound}
+	ErrDuplicateURLPath  CodedError = &codedError{"DUPLICATE_URL_PATH", "url path is already in use", http.StatusConflict}
+	ErrValidation        CodedError = &codedError{"VALIDATION_ERROR", "validation failed", http.StatusBadRequest}
+)
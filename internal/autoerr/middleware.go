@@ -0,0 +1,38 @@
+package autoerr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMapper turns the last error attached to the context (via c.Error)
+// into a {code, message, details} JSON body with the status the error
+// declares, falling back to 500 for anything that isn't a CodedError.
+func ErrorMapper() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		var coded CodedError
+		if errors.As(err, &coded) {
+			c.JSON(coded.HTTPStatus(), gin.H{
+				"code":    coded.Code(),
+				"message": coded.Error(),
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "internal server error",
+			"details": err.Error(),
+		})
+	}
+}
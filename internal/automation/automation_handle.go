@@ -2,7 +2,8 @@ package automation
 
 import (
 	"automation-hub-backend/internal/config"
-	"automation-hub-backend/internal/model"
+	"automation-hub-backend/internal/models"
+	"automation-hub-backend/internal/util"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"io"
@@ -23,26 +24,71 @@ func DefaultHandler() *Handler {
 	return NewHandler(DefaultService())
 }
 
+var imageVariantFallbacks = map[string][]string{
+	"original": {"original", "large", "medium", "thumb"},
+	"large":    {"large", "original", "medium", "thumb"},
+	"medium":   {"medium", "large", "thumb", "original"},
+	"thumb":    {"thumb", "medium", "large", "original"},
+}
+
+// ImageHandler serves a derivative of an uploaded automation image. Callers
+// must present a valid signature from util.SignImagePath - the imageName
+// path parameter is the per-automation image directory, and the "variant",
+// "expires" and "sig" query parameters authorize a specific derivative
+// (large/medium/thumb/original, default thumb), falling back to the next
+// closest variant available on disk.
 func (h *Handler) ImageHandler(c *gin.Context) {
-	imageName := c.Param("imageName")
-	imagePath := config.AppConfig.ImageSaveDir + "/" + imageName
+	imageID := c.Param("imageName")
+
+	variant := c.Query("variant")
+	if variant == "" {
+		variant = "thumb"
+	}
+	fallbacks, ok := imageVariantFallbacks[variant]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant, expected one of: original, large, medium, thumb"})
+		return
+	}
 
-	c.File(imagePath)
+	if !util.VerifyImagePath(imageID, variant, c.Query("expires"), c.Query("sig")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing, expired or invalid signature"})
+		return
+	}
+
+	automation, err := h.service.FindImageVariants(imageID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if automation == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+
+	for _, candidate := range fallbacks {
+		if fileName, ok := automation[candidate]; ok {
+			c.File(config.AppConfig.ImageSaveDir + "/" + imageID + "/" + fileName)
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
 }
 
 // Create
 // @Summary Create a new automation
-// @Description Create a new automation with the input data
+// @Description Start creating a new automation with the input data. Image
+// @Description processing and event publishing happen in the background;
+// @Description poll the returned operation to find out when it's done.
 // @Tags Automations
 // @Accept  json
 // @Produce  json
-// @Param automation body model.Automation true "Automation data"
-// @Success 201 {object} model.Automation "Successfully created automation"
+// @Param automation body models.Automation true "Automation data"
+// @Success 202 {object} operations.Operation "Automation creation started"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /automations [post]
 func (h *Handler) Create(c *gin.Context) {
-	var automation model.Automation
+	var automation models.Automation
 
 	file, _ := c.FormFile("imageFile")
 	if file != nil {
@@ -56,18 +102,19 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := model.JSON.Unmarshal(body, &automation); err != nil {
+	if err := models.JSON.Unmarshal(body, &automation); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	newAutomation, err := h.service.Create(&automation)
+	op, err := h.service.Create(&automation)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, newAutomation)
+	c.Header("Location", "/operations/"+op.ID.String())
+	c.JSON(http.StatusAccepted, op)
 }
 
 // GetAll
@@ -76,13 +123,13 @@ func (h *Handler) Create(c *gin.Context) {
 // @Tags Automations
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} model.Automation "Successfully retrieved automations"
+// @Success 200 {array} models.Automation "Successfully retrieved automations"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /automations [get]
 func (h *Handler) GetAll(c *gin.Context) {
 	automations, err := h.service.FindAll()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -96,7 +143,7 @@ func (h *Handler) GetAll(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Automation ID"
-// @Success 200 {object} model.Automation "Successfully retrieved automation"
+// @Success 200 {object} models.Automation "Successfully retrieved automation"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 404 {object} map[string]string "Not Found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
@@ -111,12 +158,7 @@ func (h *Handler) GetByID(c *gin.Context) {
 
 	automation, err := h.service.FindByID(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if automation == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Automation not found"})
+		c.Error(err)
 		return
 	}
 
@@ -125,12 +167,13 @@ func (h *Handler) GetByID(c *gin.Context) {
 
 // DeleteByID
 // @Summary Delete an automation by ID
-// @Description Delete a specific automation by its ID
+// @Description Start deleting a specific automation by its ID; poll the
+// @Description returned operation to find out when it's done.
 // @Tags Automations
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Automation ID"
-// @Success 204 "Successfully deleted automation"
+// @Success 202 {object} operations.Operation "Automation deletion started"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 404 {object} map[string]string "Not Found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
@@ -143,13 +186,14 @@ func (h *Handler) DeleteByID(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	op, err := h.service.Delete(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.Header("Location", "/operations/"+op.ID.String())
+	c.JSON(http.StatusAccepted, op)
 }
 
 // SwapPosition
@@ -183,7 +227,7 @@ func (h *Handler) SwapPosition(c *gin.Context) {
 
 	err = h.service.SwapOrder(id1, id2)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -192,18 +236,19 @@ func (h *Handler) SwapPosition(c *gin.Context) {
 
 // Update
 // @Summary Update an automation
-// @Description Update a specific automation with the input data
+// @Description Start updating a specific automation with the input data;
+// @Description poll the returned operation to find out when it's done.
 // @Tags Automations
 // @Accept  json
 // @Produce  json
-// @Param automation body model.Automation true "Automation data"
-// @Success 200 {object} model.Automation "Successfully updated automation"
+// @Param automation body models.Automation true "Automation data"
+// @Success 202 {object} operations.Operation "Automation update started"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 404 {object} map[string]string "Not Found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /automations [patch]
 func (h *Handler) Update(c *gin.Context) {
-	var automation model.Automation
+	var automation models.Automation
 
 	body, err := io.ReadAll(c.Request.Body)
 	defer c.Request.Body.Close()
@@ -212,16 +257,17 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := model.JSON.Unmarshal(body, &automation); err != nil {
+	if err := models.JSON.Unmarshal(body, &automation); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	updatedAutomation, err := h.service.Update(&automation)
+	op, err := h.service.Update(&automation)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedAutomation)
+	c.Header("Location", "/operations/"+op.ID.String())
+	c.JSON(http.StatusAccepted, op)
 }
\ No newline at end of file
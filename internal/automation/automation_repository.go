@@ -0,0 +1,110 @@
+package automation
+
+import (
+	"automation-hub-backend/internal/database"
+	"automation-hub-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository persists automations.
+type Repository interface {
+	FindByID(id uuid.UUID) (*models.Automation, error)
+	FindAll() ([]*models.Automation, error)
+	Create(automation *models.Automation) (*models.Automation, error)
+	Update(automation *models.Automation) (*models.Automation, error)
+	Delete(id uuid.UUID) error
+	MaxPosition() (int, error)
+	GetByURLPath(urlPath string) (*models.Automation, error)
+	GetByImage(image string) (*models.Automation, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+// NewRepository builds a Repository backed by the given GORM connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+// DefaultRepository returns a Repository backed by the process-wide DB pool.
+func DefaultRepository() Repository {
+	return NewRepository(database.DB())
+}
+
+func (r *gormRepository) FindByID(id uuid.UUID) (*models.Automation, error) {
+	var automation models.Automation
+	if err := r.db.First(&automation, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &automation, nil
+}
+
+func (r *gormRepository) FindAll() ([]*models.Automation, error) {
+	var automations []*models.Automation
+	if err := r.db.Order("position").Find(&automations).Error; err != nil {
+		return nil, err
+	}
+	return automations, nil
+}
+
+func (r *gormRepository) Create(automation *models.Automation) (*models.Automation, error) {
+	if err := r.db.Create(automation).Error; err != nil {
+		return nil, err
+	}
+	return automation, nil
+}
+
+func (r *gormRepository) Update(automation *models.Automation) (*models.Automation, error) {
+	if err := r.db.Save(automation).Error; err != nil {
+		return nil, err
+	}
+	return automation, nil
+}
+
+func (r *gormRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.Automation{}, "id = ?", id).Error
+}
+
+func (r *gormRepository) MaxPosition() (int, error) {
+	return maxPosition(r.db)
+}
+
+func (r *gormRepository) GetByURLPath(urlPath string) (*models.Automation, error) {
+	return getByURLPath(r.db, urlPath)
+}
+
+// maxPosition and getByURLPath take a *gorm.DB rather than a Repository
+// receiver so callers inside a Repository.Transaction can run them against
+// the tx handed to the closure instead of the bare connection - reading
+// either through r.db from inside a transaction would escape its isolation
+// and let two concurrent writers collide.
+func maxPosition(db *gorm.DB) (int, error) {
+	var max int
+	if err := db.Model(&models.Automation{}).Select("COALESCE(MAX(position), 0)").Scan(&max).Error; err != nil {
+		return 0, err
+	}
+	return max, nil
+}
+
+func getByURLPath(db *gorm.DB, urlPath string) (*models.Automation, error) {
+	var automation models.Automation
+	if err := db.First(&automation, "url_path = ?", urlPath).Error; err != nil {
+		return nil, err
+	}
+	return &automation, nil
+}
+
+func (r *gormRepository) GetByImage(image string) (*models.Automation, error) {
+	var automation models.Automation
+	if err := r.db.First(&automation, "image = ?", image).Error; err != nil {
+		return nil, err
+	}
+	return &automation, nil
+}
+
+func (r *gormRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
@@ -1,19 +1,21 @@
 package automation
 
 import (
+	"automation-hub-backend/internal/autoerr"
 	"automation-hub-backend/internal/config"
 	"automation-hub-backend/internal/events"
+	"automation-hub-backend/internal/imaging"
 	"automation-hub-backend/internal/models"
+	"automation-hub-backend/internal/operations"
 	"automation-hub-backend/internal/util"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"image"
 	"io"
 	"log"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,194 +23,317 @@ import (
 
 type Service interface {
 	FindByID(id uuid.UUID) (*models.Automation, error)
-	Create(automation *models.Automation) (*models.Automation, error)
-	Update(automation *models.Automation) (*models.Automation, error)
-	Delete(id uuid.UUID) error
+	// Create kicks off automation creation - including image processing and
+	// event publishing - in the background and returns the tracking
+	// operation immediately.
+	Create(automation *models.Automation) (*operations.Operation, error)
+	// Update kicks off an automation update in the background and returns
+	// the tracking operation immediately.
+	Update(automation *models.Automation) (*operations.Operation, error)
+	// Delete kicks off automation deletion in the background and returns
+	// the tracking operation immediately.
+	Delete(id uuid.UUID) (*operations.Operation, error)
 	FindAll() ([]*models.Automation, error)
 	SwapOrder(id1 uuid.UUID, id2 uuid.UUID) error
+	// FindImageVariants returns the variant->filename map for the automation
+	// whose Image directory is imageID, or nil if none matches.
+	FindImageVariants(imageID string) (map[string]string, error)
 }
 
 type service struct {
 	repo      Repository
 	publisher events.Publisher
+	ops       *operations.Registry
 }
 
-func NewService(repo Repository, publisher events.Publisher) Service {
+func NewService(repo Repository, publisher events.Publisher, ops *operations.Registry) Service {
 	return &service{
 		repo:      repo,
 		publisher: publisher,
+		ops:       ops,
 	}
 }
 
 func DefaultService() Service {
 	repo := DefaultRepository()
 	pub := events.DefaultPublisher()
-	return NewService(repo, *pub)
+	return NewService(repo, *pub, operations.DefaultRegistry())
 }
 
 func (s *service) FindByID(id uuid.UUID) (*models.Automation, error) {
-	return s.repo.FindByID(id)
+	automation, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+	signImageURL(automation)
+	return automation, nil
 }
 
-func (s *service) Create(automation *models.Automation) (*models.Automation, error) {
-	automation.ID = uuid.UUID{} // reset ID
+// wrapNotFound maps a gorm "record not found" into the stable autoerr
+// sentinel handlers key their 404 responses off of; any other repository
+// error is passed through unchanged.
+func wrapNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", autoerr.ErrNotFound, err)
+	}
+	return err
+}
 
-	if automation.ImageFile != nil {
-		file := automation.ImageFile
-		fmt.Printf("Received file size: %d bytes\n", file.Size)
-		fmt.Printf("Received file name: %s\n", file.Filename)
-		tempFileName := "temp_test_file" + filepath.Ext(file.Filename)
-		fullPath := config.AppConfig.ImageSaveDir + "/" + tempFileName
-		fmt.Printf("Attempting to save file to: %s\n", fullPath)
-
-		dst, err := os.Create(config.AppConfig.ImageSaveDir + "/" + tempFileName)
-		if err != nil {
-			fmt.Printf("Error creating file: %v\n", err)
-		}
-		defer dst.Close()
+// wrapDuplicateURLPath maps a unique-constraint violation on url_path into
+// the stable autoerr sentinel; any other repository error is passed through
+// unchanged.
+func wrapDuplicateURLPath(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %v", autoerr.ErrDuplicateURLPath, err)
+	}
+	return err
+}
 
-		src, err := file.Open()
-		if err != nil {
-			fmt.Printf("Error opening file: %v\n", err)
-		}
-		defer src.Close()
+// imageURLVariant is the derivative signed into models.Automation.ImageURL
+// whenever an automation is serialized.
+const imageURLVariant = "thumb"
 
-		_, err = io.Copy(dst, src)
+// signImageURL populates automation.ImageURL with a freshly signed URL to
+// its thumb variant, valid for config.AppConfig.ImageURLTTL.
+func signImageURL(automation *models.Automation) {
+	if automation == nil || automation.Image == "" {
+		return
+	}
+	automation.ImageURL = "/images/" + automation.Image +
+		util.SignImagePath(automation.Image, imageURLVariant, config.AppConfig.ImageURLTTL)
+}
+
+func (s *service) Create(automation *models.Automation) (*operations.Operation, error) {
+	resources := map[string][]string{"automations": {}}
+	op := s.ops.Add(operations.ClassTask, resources, func(ctx context.Context, op *operations.Operation) error {
+		created, err := s.createAutomation(ctx, op, automation)
 		if err != nil {
-			fmt.Printf("Error copying file: %v\n", err)
+			return err
 		}
+		signImageURL(created)
+		op.SetMetadata("automation", created)
+		op.SetResource("automations", []string{created.ID.String()})
 
-		newFileName, err := s.processImageFile(automation.ImageFile)
+		return s.publishOrWrap(events.CreateEvent, created, "create")
+	})
+	return op, nil
+}
+
+func (s *service) createAutomation(ctx context.Context, op *operations.Operation, automation *models.Automation) (*models.Automation, error) {
+	automation.ID = uuid.UUID{} // reset ID
+
+	// committed tracks whether the transaction below has already persisted
+	// automation, so a cleanup that fires after a later step (e.g. the
+	// Kafka publish) fails doesn't delete image files the DB row now
+	// references.
+	committed := false
+
+	if automation.ImageFile != nil {
+		imageID, variants, err := s.processImageFile(automation.ImageFile)
 		if err != nil {
 			return nil, err
 		}
-		automation.Image = newFileName
+		automation.Image = imageID
+		automation.ImageVariants = variants
+		op.AddCleanup(func() {
+			if committed {
+				return
+			}
+			_ = s.deleteImage(imageID)
+		})
 	}
 
-	maxPosition, err := s.repo.MaxPosition()
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	automation.Position = maxPosition + 1
 
-	err = s.ensureUniqueURLPath(automation)
-	if err != nil {
-		return nil, err
-	}
+	var created *models.Automation
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-	if err := automation.Validate(); err != nil {
-		return nil, err
-	}
+		max, err := maxPosition(tx)
+		if err != nil {
+			return err
+		}
+		automation.Position = max + 1
 
-	automationCreated, err := s.repo.Create(automation)
-	if err != nil {
-		return nil, err
-	}
-	event := &events.AutomationEvent{
-		Type:       events.CreateEvent,
-		Automation: automationCreated,
-	}
-	err = s.publisher.Publish(event)
+		if err := s.ensureUniqueURLPath(tx, automation); err != nil {
+			return err
+		}
+
+		if err := automation.Validate(); err != nil {
+			return err
+		}
+
+		if err := tx.Create(automation).Error; err != nil {
+			return wrapDuplicateURLPath(err)
+		}
+		created = automation
+		return nil
+	})
 	if err != nil {
-		log.Printf("Failed to publish create event to Kafka: %v", err)
 		return nil, err
 	}
-	return automationCreated, nil
+	committed = true
+	return created, nil
+}
+
+func (s *service) Update(automation *models.Automation) (*operations.Operation, error) {
+	resources := map[string][]string{"automations": {automation.ID.String()}}
+	op := s.ops.Add(operations.ClassTask, resources, func(ctx context.Context, op *operations.Operation) error {
+		updated, err := s.updateAutomation(ctx, op, automation)
+		if err != nil {
+			return err
+		}
+		signImageURL(updated)
+		op.SetMetadata("automation", updated)
+
+		return s.publishOrWrap(events.UpdateEvent, updated, "update")
+	})
+	return op, nil
 }
 
-func (s *service) Update(automation *models.Automation) (*models.Automation, error) {
+func (s *service) updateAutomation(ctx context.Context, op *operations.Operation, automation *models.Automation) (*models.Automation, error) {
 	currentAutomation, err := s.repo.FindByID(automation.ID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 
 	automation.Position = currentAutomation.Position
 
+	// committed tracks whether the transaction below has already persisted
+	// automation, so a cleanup that fires after a later step fails doesn't
+	// delete image files the DB row now references.
+	committed := false
+
 	if automation.ImageFile != nil {
-		newFileName, err := s.processImageFile(automation.ImageFile)
+		imageID, variants, err := s.processImageFile(automation.ImageFile)
 		if err != nil {
 			return nil, err
 		}
-		if err := s.deleteImage(currentAutomation.Image); err != nil {
-			return nil, err
-		}
-		automation.Image = newFileName
+		automation.Image = imageID
+		automation.ImageVariants = variants
+		op.AddCleanup(func() {
+			if committed {
+				return
+			}
+			_ = s.deleteImage(imageID)
+		})
 	} else if automation.RemoveImage {
-		if err := s.deleteImage(currentAutomation.Image); err != nil {
-			return nil, err
-		}
 		automation.Image = ""
+		automation.ImageVariants = nil
 	} else {
 		automation.Image = currentAutomation.Image
-	}
-
-	if currentAutomation.Name != automation.Name {
-		err = s.ensureUniqueURLPath(automation)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		automation.URLPath = currentAutomation.URLPath
+		automation.ImageVariants = currentAutomation.ImageVariants
 	}
 
 	if err := automation.Validate(); err != nil {
 		return nil, err
 	}
 
-	automationUpdated, err := s.repo.Update(automation)
+	var updated *models.Automation
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-	event := &events.AutomationEvent{
-		Type:       events.UpdateEvent,
-		Automation: automationUpdated,
-	}
+		if currentAutomation.Name != automation.Name {
+			if err := s.ensureUniqueURLPath(tx, automation); err != nil {
+				return err
+			}
+		} else {
+			automation.URLPath = currentAutomation.URLPath
+		}
 
-	err = s.publisher.Publish(event)
+		if err := tx.Save(automation).Error; err != nil {
+			return wrapDuplicateURLPath(err)
+		}
+		updated = automation
+		return nil
+	})
 	if err != nil {
-		log.Printf("Failed to publish update event to Kafka: %v", err)
 		return nil, err
 	}
+	committed = true
 
-	return automationUpdated, nil
+	if automation.ImageFile != nil || automation.RemoveImage {
+		if err := s.deleteImage(currentAutomation.Image); err != nil {
+			return nil, err
+		}
+	}
+
+	return updated, nil
 }
 
-func (s *service) Delete(id uuid.UUID) error {
-	automation, err := s.repo.FindByID(id)
-	if err != nil {
-		return err
-	}
+func (s *service) Delete(id uuid.UUID) (*operations.Operation, error) {
+	resources := map[string][]string{"automations": {id.String()}}
+	op := s.ops.Add(operations.ClassTask, resources, func(ctx context.Context, op *operations.Operation) error {
+		automation, err := s.repo.FindByID(id)
+		if err != nil {
+			return wrapNotFound(err)
+		}
 
-	err = s.repo.Delete(id)
-	if err != nil {
-		return err
-	}
+		err = s.repo.Transaction(func(tx *gorm.DB) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return tx.Delete(&models.Automation{}, "id = ?", id).Error
+		})
+		if err != nil {
+			return err
+		}
 
-	event := &events.AutomationEvent{
-		Type:       events.DeleteEvent,
-		Automation: automation,
-	}
+		if err := s.deleteImage(automation.Image); err != nil {
+			return err
+		}
 
-	err = s.publisher.Publish(event)
-	if err != nil {
-		log.Printf("Failed to publish delete event to Kafka: %v", err)
+		return s.publishOrWrap(events.DeleteEvent, automation, "delete")
+	})
+	return op, nil
+}
+
+func (s *service) publishOrWrap(eventType events.EventType, automation *models.Automation, verb string) error {
+	event := &events.AutomationEvent{Type: eventType, Automation: automation}
+	if err := s.publisher.Publish(event); err != nil {
+		log.Printf("Failed to publish %s event to Kafka: %v", verb, err)
 		return err
 	}
-
 	return nil
 }
 
 func (s *service) FindAll() ([]*models.Automation, error) {
-	return s.repo.FindAll()
+	automations, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, automation := range automations {
+		signImageURL(automation)
+	}
+	return automations, nil
+}
+
+func (s *service) FindImageVariants(imageID string) (map[string]string, error) {
+	automation, err := s.repo.GetByImage(imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return automation.ImageVariants, nil
 }
 
 func (s *service) SwapOrder(id1 uuid.UUID, id2 uuid.UUID) error {
 	return s.repo.Transaction(func(tx *gorm.DB) error {
 		automation1, err := s.repo.FindByID(id1)
 		if err != nil {
-			return err
+			return wrapNotFound(err)
 		}
 		automation2, err := s.repo.FindByID(id2)
 		if err != nil {
-			return err
+			return wrapNotFound(err)
 		}
 
 		pos1 := automation1.Position
@@ -239,89 +364,71 @@ func (s *service) SwapOrder(id1 uuid.UUID, id2 uuid.UUID) error {
 	})
 }
 
-func (s *service) processImageFile(file *multipart.FileHeader) (string, error) {
+// processImageFile validates an uploaded image by its magic bytes (not its
+// extension) and runs it through the imaging pipeline, producing a
+// per-automation directory of derivative assets. It returns the directory
+// name (used as models.Automation.Image) and the variant file names within
+// it (used as models.Automation.ImageVariants).
+func (s *service) processImageFile(file *multipart.FileHeader) (string, map[string]string, error) {
 	if file.Size > config.AppConfig.ImageMaxSize {
-		return "", fmt.Errorf("image is too large (%d). Max size is %d Mb", file.Size, config.AppConfig.ImageMaxSize)
-	}
-
-	ext := filepath.Ext(file.Filename)
-	fmt.Printf("Filename: %s, Extracted Extension: %s\n", file.Filename, ext)
-
-	if !contains(config.AppConfig.ImageExtensions, ext) {
-		return "", fmt.Errorf("invalid image extension. Allowed extensions are: %v", config.AppConfig.ImageExtensions)
+		return "", nil, fmt.Errorf("%w: %d bytes, max is %d bytes", autoerr.ErrImageTooLarge, file.Size, config.AppConfig.ImageMaxSize)
 	}
 
 	src, err := file.Open()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	defer func(src multipart.File) {
-		err := src.Close()
-		if err != nil {
-			fmt.Printf("Failed to close file: %v", err)
-		}
-	}(src)
+	defer src.Close()
 
-	buffer := make([]byte, 512)
-	_, err = src.Read(buffer)
-	if err != nil {
-		return "", err
-	}
-	fileType := http.DetectContentType(buffer)
-	if !strings.HasPrefix(fileType, "image/") {
-		return "", fmt.Errorf("file is not an image")
-	}
-	mimeSuffix := strings.TrimPrefix(fileType, "image/")
-	if !contains(config.AppConfig.ImageExtensions, "."+mimeSuffix) {
-		return "", fmt.Errorf("mismatch between file extension and MIME type")
+	header := make([]byte, 262)
+	n, err := io.ReadFull(src, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", nil, err
 	}
+	header = header[:n]
 
-	_, err = src.Seek(0, 0)
-	if err != nil {
-		return "", err
+	format := imaging.Detect(header)
+	if format == imaging.FormatUnknown {
+		return "", nil, fmt.Errorf("%w: file content is not a recognized image format", autoerr.ErrMIMEMismatch)
 	}
-
-	_, _, err = image.Decode(src)
-	if err != nil {
-		//return "", fmt.Errorf("corrupted image: %v", err)
+	if !contains(config.AppConfig.ImageExtensions, format.Extension()) {
+		return "", nil, fmt.Errorf("%w: detected format %s is not in the allowed list %v", autoerr.ErrInvalidExtension, format, config.AppConfig.ImageExtensions)
 	}
 
-	_, err = src.Seek(0, 0)
-	if err != nil {
-		return "", err
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
 	}
 
-	newFileName := uuid.New().String() + ext
-	dst, err := os.Create(config.AppConfig.ImageSaveDir + "/" + newFileName)
-	if err != nil {
-		fmt.Printf("Failed to create file %s: %v", dst.Name(), err)
-		return "", err
-	}
-	defer func(dst *os.File) {
-		err := dst.Close()
-		if err != nil {
-			fmt.Printf("Failed to close file %s: %v", dst.Name(), err)
-		}
-	}(dst)
-	fmt.Printf("Buffer content: %x\n", buffer[:100]) // Print first 100 bytes
+	imageID := uuid.New().String()
+	destDir := filepath.Join(config.AppConfig.ImageSaveDir, imageID)
 
-	_, err = io.Copy(dst, src)
+	variants, err := imaging.GenerateVariants(src, format, destDir, config.AppConfig.ImageMaxPixels)
 	if err != nil {
-		return "", err
+		var tooLarge *imaging.ErrImageTooLarge
+		switch {
+		case errors.Is(err, imaging.ErrCorrupt):
+			return "", nil, fmt.Errorf("%w: %v", autoerr.ErrCorruptImage, err)
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return "", nil, fmt.Errorf("%w: %v", autoerr.ErrUnsupportedFormat, err)
+		case errors.As(err, &tooLarge):
+			return "", nil, fmt.Errorf("%w: %v", autoerr.ErrImageTooLarge, err)
+		default:
+			return "", nil, fmt.Errorf("process image: %w", err)
+		}
 	}
 
-	return newFileName, nil
+	return imageID, variants, nil
 }
 
-func (s *service) deleteImage(imageName string) error {
-	if imageName == "" {
+func (s *service) deleteImage(imageID string) error {
+	if imageID == "" {
 		return nil
 	}
-	imagePath := config.AppConfig.ImageSaveDir + "/" + imageName
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+	imageDir := filepath.Join(config.AppConfig.ImageSaveDir, imageID)
+	if _, err := os.Stat(imageDir); os.IsNotExist(err) {
 		return nil
 	}
-	return os.Remove(imagePath)
+	return os.RemoveAll(imageDir)
 }
 
 func contains(slice []string, str string) bool {
@@ -334,16 +441,19 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
-func (s *service) ensureUniqueURLPath(automation *models.Automation) error {
+// ensureUniqueURLPath reads through db rather than s.repo so callers inside
+// a Repository.Transaction can pass the tx, keeping the uniqueness check
+// within the same transaction's isolation as the write that follows it.
+func (s *service) ensureUniqueURLPath(db *gorm.DB, automation *models.Automation) error {
 	baseURLPath := util.GenerateURLPath(automation.Name)
 	uniqueURLPath := baseURLPath
 	counter := 0
 
 	for {
-		existingAutomation, err := s.repo.GetByURLPath(uniqueURLPath)
+		existingAutomation, err := getByURLPath(db, uniqueURLPath)
 		if err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
+				return fmt.Errorf("check url path uniqueness: %w", err)
 			}
 		}
 
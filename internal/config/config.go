@@ -0,0 +1,67 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"time"
+)
+
+// Config holds the runtime configuration for the application, populated at
+// startup from environment variables / config file.
+type Config struct {
+	ImageSaveDir    string
+	ImageMaxSize    int64
+	ImageExtensions []string
+	// ImageMaxPixels caps the width*height of a decoded image before the
+	// thumbnail pipeline will process it, to bound peak memory use. Zero
+	// disables the check.
+	ImageMaxPixels int
+	// ImageURLSecret is the HMAC key used to sign image URLs so the image
+	// endpoint can reject tampered or expired links.
+	ImageURLSecret string
+	// ImageURLTTL is how long a signed image URL stays valid once issued.
+	ImageURLTTL time.Duration
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// Pidfile is where server.Server writes its PID while running, removed
+	// on clean shutdown.
+	Pidfile string
+	// ShutdownTimeout bounds how long server.Server waits for in-flight
+	// operations to finish draining before cancelling them.
+	ShutdownTimeout time.Duration
+}
+
+// AppConfig is the process-wide configuration instance.
+var AppConfig = &Config{
+	ImageSaveDir:    "uploads/images",
+	ImageMaxSize:    5 << 20, // 5 MB
+	ImageExtensions: []string{".png", ".jpg", ".jpeg", ".gif", ".webp"},
+	ImageMaxPixels:  40_000_000, // ~40MP, e.g. 8000x5000
+	ImageURLTTL:     time.Hour,
+	Addr:            ":8080",
+	Pidfile:         "automation-hub.pid",
+	ShutdownTimeout: 30 * time.Second,
+}
+
+func init() {
+	if AppConfig.ImageURLSecret != "" {
+		return
+	}
+	secret, err := randomSecret(32)
+	if err != nil {
+		log.Fatalf("config: generate ImageURLSecret: %v", err)
+	}
+	AppConfig.ImageURLSecret = secret
+	log.Println("config: ImageURLSecret not set, generated a random one for this process - signed image URLs won't survive a restart; set it explicitly to avoid that")
+}
+
+// randomSecret returns a base64url-encoded, cryptographically random secret
+// n bytes long.
+func randomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,15 @@
+package database
+
+import "gorm.io/gorm"
+
+var db *gorm.DB
+
+// Init sets the process-wide GORM connection used by DB.
+func Init(conn *gorm.DB) {
+	db = conn
+}
+
+// DB returns the process-wide GORM connection.
+func DB() *gorm.DB {
+	return db
+}
@@ -0,0 +1,45 @@
+package events
+
+import "automation-hub-backend/internal/models"
+
+// EventType identifies the kind of change an AutomationEvent carries.
+type EventType string
+
+const (
+	CreateEvent EventType = "create"
+	UpdateEvent EventType = "update"
+	DeleteEvent EventType = "delete"
+)
+
+// AutomationEvent is published whenever an automation is created, updated or
+// deleted, so downstream consumers (e.g. the frontend cache) can react.
+type AutomationEvent struct {
+	Type       EventType           `json:"type"`
+	Automation *models.Automation `json:"automation"`
+}
+
+// Publisher publishes automation events to the configured message broker.
+type Publisher interface {
+	Publish(event *AutomationEvent) error
+	// Close flushes any buffered events and releases the underlying broker
+	// connection. Callers should invoke it once, during shutdown.
+	Close() error
+}
+
+type publisher struct{}
+
+func (p publisher) Publish(_ *AutomationEvent) error {
+	return nil
+}
+
+func (p publisher) Close() error {
+	return nil
+}
+
+// DefaultPublisher returns the process-wide publisher. This implementation
+// does not send events to any broker: Publish and Close do nothing and
+// return nil.
+func DefaultPublisher() *Publisher {
+	var p Publisher = publisher{}
+	return &p
+}
@@ -0,0 +1,155 @@
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/chai2010/webp"
+	xdraw "golang.org/x/image/draw"
+)
+
+// ErrCorrupt wraps any error produced while decoding an image whose magic
+// bytes matched a known format but whose body couldn't actually be decoded.
+var ErrCorrupt = errors.New("corrupt image data")
+
+// ErrUnsupportedFormat is returned when Detect identified the format but
+// this package has no registered image.Decode support for it.
+var ErrUnsupportedFormat = errors.New("image format has no decoder")
+
+// decodable lists the formats GenerateVariants can actually decode. Detect
+// also recognizes FormatAVIF and FormatHEIC by their magic bytes so callers
+// can report a precise format name, but neither has a registered Go decoder
+// here, so GenerateVariants rejects them up front rather than letting
+// image.Decode fail and misreport them as corrupt.
+var decodable = map[Format]bool{
+	FormatPNG:  true,
+	FormatJPEG: true,
+	FormatGIF:  true,
+	FormatWebP: true,
+}
+
+// VariantSizes maps a derivative name to its longest-side target, in the
+// order they should be generated.
+var VariantSizes = []struct {
+	Name string
+	Side int
+}{
+	{"large", 1024},
+	{"medium", 512},
+	{"thumb", 128},
+}
+
+// ErrImageTooLarge is returned when a decoded image exceeds maxPixels.
+type ErrImageTooLarge struct {
+	Width, Height, MaxPixels int
+}
+
+func (e *ErrImageTooLarge) Error() string {
+	return fmt.Sprintf("decoded image is %dx%d pixels, which exceeds the %d pixel cap", e.Width, e.Height, e.MaxPixels)
+}
+
+// GenerateVariants decodes src (already sniffed as format) and writes
+// original.<ext>, large.webp, medium.webp and thumb.webp into destDir. It
+// returns a map of variant name to file name, suitable for
+// models.Automation.ImageVariants.
+func GenerateVariants(src io.ReadSeeker, format Format, destDir string, maxPixels int) (map[string]string, error) {
+	if !decodable[format] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	// Read just the header first: stdlib decoders allocate the full pixel
+	// buffer during image.Decode, so a tiny file declaring huge dimensions
+	// (a decompression bomb) would already have blown up memory by the time
+	// a post-decode bounds check ran.
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if pixels := cfg.Width * cfg.Height; maxPixels > 0 && pixels > maxPixels {
+		return nil, &ErrImageTooLarge{Width: cfg.Width, Height: cfg.Height, MaxPixels: maxPixels}
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create image dir: %w", err)
+	}
+
+	variants := make(map[string]string, len(VariantSizes)+1)
+
+	originalName := "original" + format.Extension()
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(destDir, originalName), src); err != nil {
+		return nil, fmt.Errorf("write original: %w", err)
+	}
+	variants["original"] = originalName
+
+	for _, v := range VariantSizes {
+		resized := resizeToLongestSide(img, v.Side)
+		name := v.Name + ".webp"
+		if err := encodeWebP(filepath.Join(destDir, name), resized); err != nil {
+			return nil, fmt.Errorf("encode %s variant: %w", v.Name, err)
+		}
+		variants[v.Name] = name
+	}
+
+	return variants, nil
+}
+
+// resizeToLongestSide scales img so its longest side equals target, using
+// Catmull-Rom resampling. Images already at or below target are left as-is.
+func resizeToLongestSide(img image.Image, target int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= target && height <= target {
+		return img
+	}
+
+	var newWidth, newHeight int
+	if width >= height {
+		newWidth = target
+		newHeight = height * target / width
+	} else {
+		newHeight = target
+		newWidth = width * target / height
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func encodeWebP(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return webp.Encode(f, img, &webp.Options{Quality: 85})
+}
+
+func writeFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
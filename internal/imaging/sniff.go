@@ -0,0 +1,77 @@
+package imaging
+
+import "bytes"
+
+// sniffLen is how many leading bytes of a file we inspect to identify its
+// format. 262 bytes is enough to cover every signature below, including the
+// ftyp box used by AVIF/HEIC.
+const sniffLen = 262
+
+// Format is an image format identified from its magic bytes.
+type Format string
+
+const (
+	FormatPNG     Format = "png"
+	FormatJPEG    Format = "jpeg"
+	FormatGIF     Format = "gif"
+	FormatWebP    Format = "webp"
+	FormatAVIF    Format = "avif"
+	FormatHEIC    Format = "heic"
+	FormatUnknown Format = ""
+)
+
+// Extension returns the canonical file extension for a format.
+func (f Format) Extension() string {
+	if f == FormatUnknown {
+		return ""
+	}
+	return "." + string(f)
+}
+
+var signatures = []struct {
+	format Format
+	match  func(header []byte) bool
+}{
+	{FormatPNG, func(h []byte) bool {
+		return bytes.HasPrefix(h, []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A})
+	}},
+	{FormatJPEG, func(h []byte) bool {
+		return bytes.HasPrefix(h, []byte{0xFF, 0xD8, 0xFF})
+	}},
+	{FormatGIF, func(h []byte) bool {
+		return bytes.HasPrefix(h, []byte("GIF87a")) || bytes.HasPrefix(h, []byte("GIF89a"))
+	}},
+	{FormatWebP, func(h []byte) bool {
+		return len(h) >= 12 && bytes.Equal(h[0:4], []byte("RIFF")) && bytes.Equal(h[8:12], []byte("WEBP"))
+	}},
+	{FormatAVIF, func(h []byte) bool { return matchesFtypBrand(h, "avif", "avis") }},
+	{FormatHEIC, func(h []byte) bool { return matchesFtypBrand(h, "heic", "heix", "hevc", "mif1") }},
+}
+
+// matchesFtypBrand reports whether header is an ISO-BMFF box whose ftyp
+// major brand (bytes 8-11) is one of the given four-character codes, as used
+// by AVIF and HEIC containers.
+func matchesFtypBrand(header []byte, brands ...string) bool {
+	if len(header) < 12 || !bytes.Equal(header[4:8], []byte("ftyp")) {
+		return false
+	}
+	brand := string(header[8:12])
+	for _, b := range brands {
+		if brand == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect identifies the image format of header, the leading bytes of a file
+// (at least sniffLen bytes, fewer is fine for small files). It returns
+// FormatUnknown if no known signature matches.
+func Detect(header []byte) Format {
+	for _, sig := range signatures {
+		if sig.match(header) {
+			return sig.format
+		}
+	}
+	return FormatUnknown
+}
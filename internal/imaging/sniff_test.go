@@ -0,0 +1,49 @@
+package imaging
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name   string
+		header []byte
+		want   Format
+	}{
+		{"png", []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, FormatPNG},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10}, FormatJPEG},
+		{"gif87a", []byte("GIF87a" + "extra bytes"), FormatGIF},
+		{"gif89a", []byte("GIF89a" + "extra bytes"), FormatGIF},
+		{"webp", append([]byte("RIFF\x00\x00\x00\x00"), []byte("WEBPVP8 ")...), FormatWebP},
+		{"avif", ftypHeader("avif"), FormatAVIF},
+		{"avis", ftypHeader("avis"), FormatAVIF},
+		{"heic", ftypHeader("heic"), FormatHEIC},
+		{"heix", ftypHeader("heix"), FormatHEIC},
+		{"unknown", []byte("not an image"), FormatUnknown},
+		{"empty", nil, FormatUnknown},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Detect(tc.header); got != tc.want {
+				t.Errorf("Detect(%q) = %q, want %q", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatExtension(t *testing.T) {
+	if got := FormatPNG.Extension(); got != ".png" {
+		t.Errorf("FormatPNG.Extension() = %q, want %q", got, ".png")
+	}
+	if got := FormatUnknown.Extension(); got != "" {
+		t.Errorf("FormatUnknown.Extension() = %q, want empty", got)
+	}
+}
+
+// ftypHeader builds a minimal ISO-BMFF header with the given major brand,
+// enough for matchesFtypBrand to recognize it.
+func ftypHeader(brand string) []byte {
+	h := make([]byte, 12)
+	copy(h[4:8], "ftyp")
+	copy(h[8:12], brand)
+	return h
+}
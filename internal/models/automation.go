@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"automation-hub-backend/internal/autoerr"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSON is the jsoniter instance used to (un)marshal automations, kept
+// distinct from encoding/json so field-level tags stay compatible with it.
+var JSON = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Automation represents a single automation card managed by the hub.
+type Automation struct {
+	ID          uuid.UUID             `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	URLPath     string                `json:"urlPath" gorm:"uniqueIndex"`
+	Image       string                `json:"image"`
+	Position    int                   `json:"position"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+	ImageFile   *multipart.FileHeader `json:"-" gorm:"-"`
+	RemoveImage bool                  `json:"removeImage,omitempty" gorm:"-"`
+
+	// ImageVariants maps a derivative name (original, large, medium, thumb)
+	// to the file name produced for it by the imaging pipeline.
+	ImageVariants map[string]string `json:"imageVariants,omitempty" gorm:"serializer:json"`
+
+	// ImageURL is a freshly signed, expiring link to the image, computed at
+	// serialization time rather than stored.
+	ImageURL string `json:"imageUrl,omitempty" gorm:"-"`
+}
+
+// Validate checks that the automation is in a persistable state.
+func (a *Automation) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("%w: %v", autoerr.ErrValidation, errNameRequired)
+	}
+	return nil
+}
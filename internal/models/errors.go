@@ -0,0 +1,5 @@
+package models
+
+import "errors"
+
+var errNameRequired = errors.New("name is required")
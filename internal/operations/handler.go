@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler exposes the operation registry over HTTP so clients can poll,
+// long-poll or cancel a background Create/Update/Delete.
+type Handler struct {
+	registry *Registry
+}
+
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{registry: registry}
+}
+
+func DefaultHandler() *Handler {
+	return NewHandler(DefaultRegistry())
+}
+
+// List
+// @Summary List operations
+// @Description Retrieve every known operation
+// @Tags Operations
+// @Produce  json
+// @Success 200 {array} operations.Operation
+// @Router /operations [get]
+func (h *Handler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registry.List())
+}
+
+// Get
+// @Summary Get an operation by ID
+// @Tags Operations
+// @Produce  json
+// @Param id path string true "Operation ID"
+// @Success 200 {object} operations.Operation
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /operations/{id} [get]
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operation id"})
+		return
+	}
+
+	op, err := h.registry.Get(id)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, op)
+}
+
+// maxWaitTimeout caps the client-supplied "timeout" query param on Wait, so
+// a single long-poll request can't hold its connection open indefinitely -
+// including across a graceful shutdown's bounded http.Server.Shutdown.
+const maxWaitTimeout = 2 * time.Minute
+
+// Wait
+// @Summary Long-poll an operation until it finishes
+// @Tags Operations
+// @Produce  json
+// @Param id path string true "Operation ID"
+// @Param timeout query string false "Max time to wait, e.g. 30s (capped at 2m)"
+// @Success 200 {object} operations.Operation
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /operations/{id}/wait [get]
+func (h *Handler) Wait(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operation id"})
+		return
+	}
+
+	timeout := 30 * time.Second
+	if raw := c.Query("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timeout"})
+			return
+		}
+		timeout = parsed
+	}
+	if timeout <= 0 || timeout > maxWaitTimeout {
+		timeout = maxWaitTimeout
+	}
+
+	op, err := h.registry.WaitFor(c.Request.Context(), id, timeout)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, op)
+}
+
+// Cancel
+// @Summary Cancel a running operation
+// @Tags Operations
+// @Produce  json
+// @Param id path string true "Operation ID"
+// @Success 204 "Successfully cancelled"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /operations/{id} [delete]
+func (h *Handler) Cancel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operation id"})
+		return
+	}
+
+	if err := h.registry.Cancel(id); err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) writeLookupError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "operation not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
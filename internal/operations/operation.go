@@ -0,0 +1,123 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"automation-hub-backend/internal/autoerr"
+	"github.com/google/uuid"
+)
+
+// Class identifies how a client is expected to track an operation's
+// progress.
+type Class string
+
+const (
+	ClassTask      Class = "task"
+	ClassWebsocket Class = "websocket"
+)
+
+// Status is the lifecycle state of an Operation.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSuccess   Status = "success"
+	StatusFailure   Status = "failure"
+	StatusCancelled Status = "cancelled"
+)
+
+// RunFunc is the work an Operation performs. It must respect ctx
+// cancellation so Registry.Cancel can interrupt it promptly.
+type RunFunc func(ctx context.Context, op *Operation) error
+
+// Operation tracks a long-running background task, LXD-style: a client gets
+// one back immediately and polls or waits on it instead of blocking the
+// original request.
+type Operation struct {
+	ID        uuid.UUID           `json:"id"`
+	Class     Class               `json:"class"`
+	Status    Status              `json:"status"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	Resources map[string][]string `json:"resources,omitempty"`
+	Metadata  map[string]any      `json:"metadata,omitempty"`
+	Err       string              `json:"err,omitempty"`
+	// Code is the stable autoerr code for Err, set when run failed with a
+	// autoerr.CodedError, so clients can switch on it instead of parsing
+	// Err's English text.
+	Code string `json:"code,omitempty"`
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	cleanup []func()
+}
+
+// AddCleanup registers a rollback action to run if the operation is
+// cancelled before it completes, e.g. removing files written to disk.
+func (op *Operation) AddCleanup(fn func()) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.cleanup = append(op.cleanup, fn)
+}
+
+// SetMetadata safely sets a metadata key from within a running RunFunc.
+// Writing op.Metadata directly races with the struct's JSON serialization,
+// which can happen concurrently from any GET /operations/{id} or /wait
+// while the operation is still running.
+func (op *Operation) SetMetadata(key string, value any) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.Metadata[key] = value
+}
+
+// SetResource safely replaces the resource ids tracked under kind from
+// within a running RunFunc, for the same reason SetMetadata exists.
+func (op *Operation) SetResource(kind string, ids []string) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.Resources[kind] = ids
+}
+
+func (op *Operation) setStatus(status Status, runErr error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.Status = status
+	op.UpdatedAt = time.Now()
+	if runErr != nil {
+		op.Err = runErr.Error()
+		var coded autoerr.CodedError
+		if errors.As(runErr, &coded) {
+			op.Code = coded.Code()
+		}
+	}
+}
+
+// snapshot copies op under lock, including a deep copy of Metadata and
+// Resources - a shallow copy would still share the underlying maps with the
+// live operation, so a caller marshalling the snapshot could race with a
+// RunFunc still mutating it via SetMetadata/SetResource.
+func (op *Operation) snapshot() *Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	clone := *op
+	clone.mu = sync.Mutex{}
+
+	clone.Metadata = make(map[string]any, len(op.Metadata))
+	for k, v := range op.Metadata {
+		clone.Metadata[k] = v
+	}
+
+	clone.Resources = make(map[string][]string, len(op.Resources))
+	for k, v := range op.Resources {
+		ids := make([]string, len(v))
+		copy(ids, v)
+		clone.Resources[k] = ids
+	}
+
+	return &clone
+}
@@ -0,0 +1,185 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when an operation ID is not known to the registry.
+var ErrNotFound = fmt.Errorf("operation not found")
+
+// Registry tracks in-flight and completed operations. Operations are kept
+// in memory only; a process restart loses history of past operations.
+type Registry struct {
+	mu  sync.RWMutex
+	ops map[uuid.UUID]*Operation
+}
+
+// NewRegistry builds an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{ops: make(map[uuid.UUID]*Operation)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the process-wide operation registry.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Add creates an operation already in the Running status and starts run in
+// a new goroutine, then returns the operation. The caller gets
+// back an Operation it can poll or wait on without blocking for run to
+// finish.
+func (r *Registry) Add(class Class, resources map[string][]string, run RunFunc) *Operation {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	op := &Operation{
+		ID:        uuid.New(),
+		Class:     class,
+		Status:    StatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Resources: resources,
+		Metadata:  map[string]any{},
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	r.ops[op.ID] = op
+	r.mu.Unlock()
+
+	go func() {
+		defer close(op.done)
+		err := run(ctx, op)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			op.runCleanup()
+			op.setStatus(StatusCancelled, err)
+		case err != nil:
+			op.setStatus(StatusFailure, err)
+		default:
+			op.setStatus(StatusSuccess, nil)
+		}
+	}()
+
+	return op
+}
+
+func (op *Operation) runCleanup() {
+	op.mu.Lock()
+	cleanup := op.cleanup
+	op.mu.Unlock()
+	for _, fn := range cleanup {
+		fn()
+	}
+}
+
+// Get returns the operation with the given ID.
+func (r *Registry) Get(id uuid.UUID) (*Operation, error) {
+	r.mu.RLock()
+	op, ok := r.ops[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return op.snapshot(), nil
+}
+
+// List returns every operation known to the registry.
+func (r *Registry) List() []*Operation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		list = append(list, op.snapshot())
+	}
+	return list
+}
+
+// WaitFor blocks until the operation reaches a terminal status, ctx is done,
+// or timeout elapses, whichever comes first.
+func (r *Registry) WaitFor(ctx context.Context, id uuid.UUID, timeout time.Duration) (*Operation, error) {
+	r.mu.RLock()
+	op, ok := r.ops[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	select {
+	case <-op.done:
+	case <-waitCtx.Done():
+	}
+	return op.snapshot(), nil
+}
+
+// DrainWait waits for every operation known to the registry to reach a
+// terminal status. Any operation still running once timeout elapses is
+// cancelled, so a shutting-down process doesn't leave half-written image
+// files or half-committed transactions behind.
+func (r *Registry) DrainWait(ctx context.Context, timeout time.Duration) {
+	r.mu.RLock()
+	pending := make([]*Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		pending = append(pending, op)
+	}
+	r.mu.RUnlock()
+
+	deadline := time.Now().Add(timeout)
+	for _, op := range pending {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		timer := time.NewTimer(remaining)
+		select {
+		case <-op.done:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			op.cancel()
+			<-op.done
+		case <-timer.C:
+			op.cancel()
+			<-op.done
+		}
+	}
+}
+
+// Cancel cancels the running operation's context and waits for its run
+// function to return. The registered cleanup actions run only if run then
+// returns an error; a run that returns nil despite the cancel ends as
+// Success with no cleanup. It is a no-op if the operation has already
+// finished.
+func (r *Registry) Cancel(id uuid.UUID) error {
+	r.mu.RLock()
+	op, ok := r.ops[id]
+	r.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	op.mu.Lock()
+	if op.Status != StatusPending && op.Status != StatusRunning {
+		op.mu.Unlock()
+		return nil
+	}
+	op.mu.Unlock()
+
+	op.cancel()
+	<-op.done
+	return nil
+}
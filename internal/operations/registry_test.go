@@ -0,0 +1,125 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"automation-hub-backend/internal/autoerr"
+	"github.com/google/uuid"
+)
+
+func TestRegistryAddWaitForSuccess(t *testing.T) {
+	r := NewRegistry()
+	op := r.Add(ClassTask, map[string][]string{"things": {"a"}}, func(ctx context.Context, op *Operation) error {
+		op.SetMetadata("done", true)
+		return nil
+	})
+
+	got, err := r.WaitFor(context.Background(), op.ID, time.Second)
+	if err != nil {
+		t.Fatalf("WaitFor: %v", err)
+	}
+	if got.Status != StatusSuccess {
+		t.Fatalf("Status = %v, want %v", got.Status, StatusSuccess)
+	}
+	if got.Metadata["done"] != true {
+		t.Fatalf("Metadata[done] = %v, want true", got.Metadata["done"])
+	}
+}
+
+func TestRegistryAddFailureSetsCode(t *testing.T) {
+	r := NewRegistry()
+	op := r.Add(ClassTask, nil, func(ctx context.Context, op *Operation) error {
+		return fmt.Errorf("wrap: %w", autoerr.ErrNotFound)
+	})
+
+	got, err := r.WaitFor(context.Background(), op.ID, time.Second)
+	if err != nil {
+		t.Fatalf("WaitFor: %v", err)
+	}
+	if got.Status != StatusFailure {
+		t.Fatalf("Status = %v, want %v", got.Status, StatusFailure)
+	}
+	if got.Code != autoerr.ErrNotFound.Code() {
+		t.Fatalf("Code = %q, want %q", got.Code, autoerr.ErrNotFound.Code())
+	}
+}
+
+func TestRegistryCancelRunsCleanup(t *testing.T) {
+	r := NewRegistry()
+	started := make(chan struct{})
+	cleaned := make(chan struct{})
+
+	op := r.Add(ClassTask, nil, func(ctx context.Context, op *Operation) error {
+		op.AddCleanup(func() { close(cleaned) })
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	<-started
+	if err := r.Cancel(op.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	select {
+	case <-cleaned:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup did not run after cancel")
+	}
+
+	got, err := r.Get(op.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusCancelled {
+		t.Fatalf("Status = %v, want %v", got.Status, StatusCancelled)
+	}
+}
+
+func TestRegistryGetUnknown(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Get(uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestOperationConcurrentMetadataAndSnapshot exercises SetMetadata and
+// snapshot (via Registry.Get) from many goroutines at once. It's meant to be
+// run with `go test -race`: before SetMetadata/snapshot routed every
+// Metadata/Resources access through op.mu, this reproduced a concurrent map
+// read/write between a running RunFunc and a GET /operations/{id} response.
+func TestOperationConcurrentMetadataAndSnapshot(t *testing.T) {
+	r := NewRegistry()
+	release := make(chan struct{})
+	op := r.Add(ClassTask, map[string][]string{"things": {}}, func(ctx context.Context, op *Operation) error {
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			op.SetMetadata(fmt.Sprintf("key-%d", i), i)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := r.Get(op.ID); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+
+	if _, err := r.WaitFor(context.Background(), op.ID, time.Second); err != nil {
+		t.Fatalf("WaitFor: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package server wires the Gin engine, the operation registry, the event
+// publisher and the database connection into a single process lifecycle:
+// a pidfile while running, a readiness endpoint load balancers can watch,
+// and a shutdown sequence that drains in-flight work before exiting.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+
+	"automation-hub-backend/internal/config"
+	"automation-hub-backend/internal/events"
+	"automation-hub-backend/internal/operations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Server owns the HTTP listener and the process-wide dependencies that need
+// an orderly shutdown: operations in flight, the event publisher and the
+// database pool.
+type Server struct {
+	httpServer *http.Server
+	ops        *operations.Registry
+	publisher  events.Publisher
+	db         *gorm.DB
+
+	ready atomic.Bool
+}
+
+// NewServer builds a Server around an already-routed Gin engine. Callers are
+// responsible for registering their own routes on engine before calling Run;
+// NewServer additionally registers GET /healthz.
+func NewServer(engine *gin.Engine, ops *operations.Registry, publisher events.Publisher, db *gorm.DB) *Server {
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:    config.AppConfig.Addr,
+			Handler: engine,
+		},
+		ops:       ops,
+		publisher: publisher,
+		db:        db,
+	}
+	s.ready.Store(true)
+	engine.GET("/healthz", s.healthz)
+	return s
+}
+
+// healthz reports the server ready until shutdown begins draining in-flight
+// work, so a load balancer stops routing new requests before then.
+func (s *Server) healthz(c *gin.Context) {
+	if !s.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Run writes the pidfile and serves HTTP until ctx is cancelled or the
+// process receives SIGINT, SIGTERM or SIGQUIT, then runs Shutdown. It
+// returns once the shutdown sequence has completed.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	if err := s.writePidfile(); err != nil {
+		return fmt.Errorf("write pidfile: %w", err)
+	}
+	defer s.removePidfile()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+			return
+		}
+		serveErr <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		return s.Shutdown(context.Background())
+	case err := <-serveErr:
+		return err
+	}
+}
+
+// Shutdown stops accepting new requests, waits for in-flight Create/Update
+// operations to finish or be cancelled, flushes the event publisher, closes
+// the database pool and removes the pidfile. It is safe to call directly
+// (e.g. from a signal handler) instead of going through Run.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.ready.Store(false)
+
+	// http.Server.Shutdown otherwise waits indefinitely for active
+	// connections to go idle - including a long-polling GET
+	// /operations/{id}/wait - so bound it the same way DrainWait is bounded
+	// below, instead of letting one slow client block shutdown forever.
+	shutdownCtx, cancel := context.WithTimeout(ctx, config.AppConfig.ShutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+
+	s.ops.DrainWait(ctx, config.AppConfig.ShutdownTimeout)
+
+	if err := s.publisher.Close(); err != nil {
+		log.Printf("flush event publisher: %v", err)
+	}
+
+	if sqlDB, err := s.db.DB(); err != nil {
+		log.Printf("get underlying db connection: %v", err)
+	} else if err := sqlDB.Close(); err != nil {
+		log.Printf("close db pool: %v", err)
+	}
+
+	s.removePidfile()
+	return nil
+}
+
+func (s *Server) writePidfile() error {
+	if config.AppConfig.Pidfile == "" {
+		return nil
+	}
+	return os.WriteFile(config.AppConfig.Pidfile, []byte(strconv.Itoa(os.Getpid())), 0o644)
+}
+
+func (s *Server) removePidfile() {
+	if config.AppConfig.Pidfile == "" {
+		return
+	}
+	if err := os.Remove(config.AppConfig.Pidfile); err != nil && !os.IsNotExist(err) {
+		log.Printf("remove pidfile: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"automation-hub-backend/internal/config"
+)
+
+// SignImagePath builds the query string for a signed, expiring image URL:
+// "?expires=<unix>&sig=<base64(hmac-sha256)>&variant=<variant>". The
+// signature binds the image name, the variant and the expiry together, so
+// none of them can be swapped independently.
+func SignImagePath(name string, variant string, ttl time.Duration) string {
+	expires := time.Now().Add(ttl).Unix()
+	sig := signaturePayload(name, variant, expires)
+
+	values := url.Values{}
+	values.Set("variant", variant)
+	values.Set("expires", strconv.FormatInt(expires, 10))
+	values.Set("sig", sig)
+	return "?" + values.Encode()
+}
+
+// VerifyImagePath checks that sig is a valid, unexpired signature for name
+// and variant, produced by SignImagePath.
+func VerifyImagePath(name, variant, expiresStr, sig string) bool {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	if time.Now().Unix() > expires {
+		return false
+	}
+
+	expected := signaturePayload(name, variant, expires)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(sig)) == 1
+}
+
+func signaturePayload(name, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.ImageURLSecret))
+	fmt.Fprintf(mac, "%s|%s|%d", name, variant, expires)
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
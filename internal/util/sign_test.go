@@ -0,0 +1,74 @@
+package util
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"automation-hub-backend/internal/config"
+)
+
+func TestSignAndVerifyImagePath(t *testing.T) {
+	qs := SignImagePath("image-1", "thumb", time.Hour)
+	values, err := url.ParseQuery(qs[1:]) // strip leading "?"
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+
+	if !VerifyImagePath("image-1", "thumb", values.Get("expires"), values.Get("sig")) {
+		t.Fatal("VerifyImagePath rejected a freshly signed URL")
+	}
+}
+
+func TestVerifyImagePathExpired(t *testing.T) {
+	qs := SignImagePath("image-1", "thumb", -time.Minute)
+	values, _ := url.ParseQuery(qs[1:])
+
+	if VerifyImagePath("image-1", "thumb", values.Get("expires"), values.Get("sig")) {
+		t.Fatal("VerifyImagePath accepted an expired signature")
+	}
+}
+
+func TestVerifyImagePathTamperedName(t *testing.T) {
+	qs := SignImagePath("image-1", "thumb", time.Hour)
+	values, _ := url.ParseQuery(qs[1:])
+
+	if VerifyImagePath("image-2", "thumb", values.Get("expires"), values.Get("sig")) {
+		t.Fatal("VerifyImagePath accepted a signature for a different image id")
+	}
+}
+
+func TestVerifyImagePathTamperedVariant(t *testing.T) {
+	qs := SignImagePath("image-1", "thumb", time.Hour)
+	values, _ := url.ParseQuery(qs[1:])
+
+	if VerifyImagePath("image-1", "large", values.Get("expires"), values.Get("sig")) {
+		t.Fatal("VerifyImagePath accepted a signature for a different variant")
+	}
+}
+
+func TestVerifyImagePathTamperedExpiry(t *testing.T) {
+	qs := SignImagePath("image-1", "thumb", time.Hour)
+	values, _ := url.ParseQuery(qs[1:])
+
+	laterExpiry := strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10)
+	if VerifyImagePath("image-1", "thumb", laterExpiry, values.Get("sig")) {
+		t.Fatal("VerifyImagePath accepted a signature against an extended expiry")
+	}
+}
+
+func TestVerifyImagePathWrongSecret(t *testing.T) {
+	original := config.AppConfig.ImageURLSecret
+	defer func() { config.AppConfig.ImageURLSecret = original }()
+
+	expires := time.Now().Add(time.Hour).Unix()
+
+	config.AppConfig.ImageURLSecret = "attacker-controlled-secret"
+	forged := signaturePayload("image-1", "thumb", expires)
+	config.AppConfig.ImageURLSecret = original
+
+	if VerifyImagePath("image-1", "thumb", strconv.FormatInt(expires, 10), forged) {
+		t.Fatal("VerifyImagePath accepted a signature produced with a different secret")
+	}
+}
@@ -0,0 +1,14 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+var urlPathSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// GenerateURLPath turns an automation name into a URL-friendly slug.
+func GenerateURLPath(name string) string {
+	slug := urlPathSanitizer.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}